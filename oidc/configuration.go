@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -176,6 +177,62 @@ type Configuration struct {
 	// registration process SHOULD display this URL to the person registering
 	// the Client if it is given.
 	OpTOSURI string `json:"op_tos_uri"`
+
+	// OPTIONAL. URL of the OP's OAuth 2.0 Introspection Endpoint, as defined
+	// in RFC 7662.
+	IntrospectionEndpoint string `json:"introspection_endpoint,omitempty"`
+	// OPTIONAL. URL of the OP's OAuth 2.0 Revocation Endpoint, as defined in
+	// RFC 7009.
+	RevocationEndpoint string `json:"revocation_endpoint,omitempty"`
+	// OPTIONAL. URL at the OP to which an RP can redirect a Relying Party's
+	// End-User to request that the End-User be logged out at the OP, as
+	// defined by OpenID Connect RP-Initiated Logout 1.0.
+	EndSessionEndpoint string `json:"end_session_endpoint,omitempty"`
+	// OPTIONAL. URL of the OP's Pushed Authorization Request endpoint, as
+	// defined in RFC 9126.
+	PushedAuthorizationRequestEndpoint string `json:"pushed_authorization_request_endpoint,omitempty"`
+	// OPTIONAL. Boolean value specifying whether the OP requires pushed
+	// authorization requests, as defined in RFC 9126. If omitted, the
+	// default value is false.
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests,omitempty"`
+	// OPTIONAL. URL of the OP's Request Object Endpoint, to which Request
+	// Objects may be pushed by value ahead of the authorization request, as
+	// used by some FAPI profiles.
+	RequestObjectEndpoint string `json:"request_object_endpoint,omitempty"`
+	// OPTIONAL. Alternative endpoint URLs to use when authenticating with
+	// mutual TLS, as defined in RFC 8705.
+	MTLSEndpointAliases *MTLSEndpointAliases `json:"mtls_endpoint_aliases,omitempty"`
+	// OPTIONAL. Boolean value specifying whether the OP supports issuing
+	// access tokens bound to a client's mutual TLS certificate, as defined
+	// in RFC 8705. If omitted, the default value is false.
+	TLSClientCertificateBoundAccessTokens bool `json:"tls_client_certificate_bound_access_tokens,omitempty"`
+	// OPTIONAL. Boolean value specifying whether the OP supports back-channel
+	// logout, as defined by OpenID Connect Back-Channel Logout 1.0.
+	BackchannelLogoutSupported bool `json:"backchannel_logout_supported,omitempty"`
+	// OPTIONAL. Boolean value specifying whether the OP supports front-channel
+	// logout, as defined by OpenID Connect Front-Channel Logout 1.0.
+	FrontchannelLogoutSupported bool `json:"frontchannel_logout_supported,omitempty"`
+	// OPTIONAL. JSON array containing a list of PKCE code_challenge_method
+	// values supported by the Authorization Endpoint, as defined in RFC 7636.
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+	// OPTIONAL. JSON array containing a list of the JWS signing algorithms
+	// supported by the Authorization Endpoint for signing the JWT returned
+	// from it, as used by JARM-profiled OPs such as those following FAPI.
+	AuthorizationSigningAlgValuesSupported []string `json:"authorization_signing_alg_values_supported,omitempty"`
+	// OPTIONAL. JSON array containing a list of the JWS signing algorithms
+	// supported by IntrospectionEndpoint for signing its responses.
+	IntrospectionSigningAlgValuesSupported []string `json:"introspection_signing_alg_values_supported,omitempty"`
+}
+
+// MTLSEndpointAliases lists alternative endpoint URLs to use when
+// authenticating with mutual TLS, as advertised under mtls_endpoint_aliases
+// by OPs that support RFC 8705 (e.g. Keycloak, Gravitee AM).
+type MTLSEndpointAliases struct {
+	TokenEndpoint                      string `json:"token_endpoint,omitempty"`
+	RevocationEndpoint                 string `json:"revocation_endpoint,omitempty"`
+	IntrospectionEndpoint              string `json:"introspection_endpoint,omitempty"`
+	PushedAuthorizationRequestEndpoint string `json:"pushed_authorization_request_endpoint,omitempty"`
+	UserInfoEndpoint                   string `json:"userinfo_endpoint,omitempty"`
 }
 
 // Valid checks that all the fields marked as required are present.
@@ -198,6 +255,9 @@ func (config Configuration) Valid() error {
 	if len(config.IDTokenSigningAlgValuesSupported) == 0 {
 		return errors.New("authproxy/oidc: id_token_signing_alg_values_supported is empty")
 	}
+	if config.RequirePushedAuthorizationRequests && config.PushedAuthorizationRequestEndpoint == "" {
+		return errors.New("authproxy/oidc: require_pushed_authorization_requests is set but pushed_authorization_request_endpoint is empty")
+	}
 
 	return nil
 }
@@ -207,6 +267,11 @@ func (config Configuration) Valid() error {
 //  - GrantTypesSupported: ["authorization_code", "implicit"]
 //  - TokenEndpointAuthMethodsSupported: ["client_secret_basic"]
 //  - RequestURIParameterSupported: true
+//
+// CodeChallengeMethodsSupported is intentionally left untouched: the spec
+// defines no default for it, so an empty value means PKCE support is
+// unadvertised rather than absent. OPs that do want to advertise it should
+// set it explicitly, e.g. via WithCoreDefaults.
 func (config *Configuration) FillDefaultValuesIfEmpty() {
 	if len(config.ResponseModesSupported) == 0 {
 		config.ResponseModesSupported = []string{"query", "fragment"}
@@ -223,8 +288,53 @@ func (config *Configuration) FillDefaultValuesIfEmpty() {
 	}
 }
 
-// GetOpenIDConnectConfiguration from a well-known url. Should be Issuer + /.well-known/openid-configuration
-func GetOpenIDConnectConfiguration(ctx context.Context, client *http.Client, url string) (config Configuration, err error) {
+// ErrIssuerMismatch is returned by GetOpenIDConnectConfiguration when the
+// discovered Issuer doesn't match the issuer that was requested.
+var ErrIssuerMismatch = errors.New("authproxy/oidc: issuer in discovered configuration doesn't match the requested issuer")
+
+// ErrInsecureEndpoint is returned by GetOpenIDConnectConfiguration when a
+// discovered endpoint doesn't use https, unless AllowInsecureEndpoints was
+// given.
+var ErrInsecureEndpoint = errors.New("authproxy/oidc: discovered endpoint doesn't use https")
+
+// ErrMissingRS256 is returned by GetOpenIDConnectConfiguration when the
+// discovered id_token_signing_alg_values_supported doesn't contain RS256,
+// as mandated by OpenID Connect Discovery 1.0.
+var ErrMissingRS256 = errors.New("authproxy/oidc: id_token_signing_alg_values_supported doesn't contain RS256")
+
+// ConfigurationOption configures the validation GetOpenIDConnectConfiguration
+// performs on a discovered Configuration.
+type ConfigurationOption func(*configurationOptions)
+
+type configurationOptions struct {
+	allowInsecureEndpoints bool
+	strict                 bool
+}
+
+// AllowInsecureEndpoints disables the https requirement on discovered
+// endpoints, for use against localhost or other development providers.
+func AllowInsecureEndpoints() ConfigurationOption {
+	return func(o *configurationOptions) {
+		o.allowInsecureEndpoints = true
+	}
+}
+
+// StrictMode additionally rejects "none" from any JWS signing algorithm list
+// in the discovered Configuration.
+func StrictMode() ConfigurationOption {
+	return func(o *configurationOptions) {
+		o.strict = true
+	}
+}
+
+// GetOpenIDConnectConfiguration from a well-known url. Should be Issuer + /.well-known/openid-configuration.
+//
+// The discovered Configuration is validated against the OpenID Connect
+// Discovery 1.0 security requirements: its Issuer must match the requested
+// issuer, its endpoints must use https (unless AllowInsecureEndpoints is
+// given), and it must advertise support for RS256 ID token signing. See
+// ErrIssuerMismatch, ErrInsecureEndpoint and ErrMissingRS256.
+func GetOpenIDConnectConfiguration(ctx context.Context, client *http.Client, url string, opts ...ConfigurationOption) (config Configuration, err error) {
 	if client == nil {
 		client = http.DefaultClient
 	}
@@ -258,5 +368,71 @@ func GetOpenIDConnectConfiguration(ctx context.Context, client *http.Client, url
 
 	config.FillDefaultValuesIfEmpty()
 
+	o := &configurationOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := validateDiscoveredConfiguration(url, config, o); err != nil {
+		return Configuration{}, err
+	}
+
 	return config, nil
 }
+
+// validateDiscoveredConfiguration enforces the security requirements of
+// OpenID Connect Discovery 1.0 Section 4.3 that json.Decode alone doesn't.
+func validateDiscoveredConfiguration(requestURL string, config Configuration, o *configurationOptions) error {
+	requestedIssuer := strings.TrimSuffix(requestURL, "/.well-known/openid-configuration")
+	requestedIssuer = strings.TrimSuffix(requestedIssuer, "/")
+
+	if strings.TrimSuffix(config.Issuer, "/") != requestedIssuer {
+		return errors.Wrapf(ErrIssuerMismatch, "got %q, requested %q", config.Issuer, requestedIssuer)
+	}
+
+	if !o.allowInsecureEndpoints {
+		endpoints := []string{
+			config.Issuer,
+			config.AuthorizationEndpoint,
+			config.TokenEndpoint,
+			config.UserInfoEndpoint,
+			config.JWKSURI,
+		}
+		for _, endpoint := range endpoints {
+			if endpoint != "" && !strings.HasPrefix(endpoint, "https://") {
+				return errors.Wrapf(ErrInsecureEndpoint, "%q", endpoint)
+			}
+		}
+	}
+
+	var hasRS256 bool
+	for _, alg := range config.IDTokenSigningAlgValuesSupported {
+		if alg == "RS256" {
+			hasRS256 = true
+		}
+		if o.strict && alg == "none" {
+			return errors.New("authproxy/oidc: id_token_signing_alg_values_supported contains \"none\" in strict mode")
+		}
+	}
+	if !hasRS256 {
+		return ErrMissingRS256
+	}
+
+	if o.strict {
+		for _, algs := range [][]string{
+			config.UserInfoSigningAlgValuesSupported,
+			config.RequestObjectSigningAlgValuesSupported,
+			config.TokenEndpointAuthSigningAlgValuesSupported,
+			config.AuthorizationSigningAlgValuesSupported,
+			config.IntrospectionSigningAlgValuesSupported,
+		} {
+			for _, alg := range algs {
+				if alg == "none" {
+					return errors.New("authproxy/oidc: a signing algorithm list contains \"none\" in strict mode")
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// WellKnownOpenIDConfigurationPath is the path OIDC Discovery 1.0
+	// providers serve their metadata document at.
+	WellKnownOpenIDConfigurationPath = "/.well-known/openid-configuration"
+	// WellKnownOAuthAuthorizationServerPath is the RFC 8414 path some
+	// OAuth 2.0-only clients look up instead of
+	// WellKnownOpenIDConfigurationPath.
+	WellKnownOAuthAuthorizationServerPath = "/.well-known/oauth-authorization-server"
+)
+
+// Option configures a Configuration and the handler serving it before
+// NewConfigurationHandler builds the final metadata document.
+type Option func(cfg *Configuration, o *handlerOptions)
+
+type handlerOptions struct {
+	serveOAuthAuthorizationServerAlias bool
+}
+
+// WithCoreDefaults populates cfg with the values expected of a Core-
+// compliant OpenID Provider, without overriding anything already set.
+func WithCoreDefaults() Option {
+	return func(cfg *Configuration, _ *handlerOptions) {
+		if len(cfg.ResponseTypesSupported) == 0 {
+			cfg.ResponseTypesSupported = []string{"code", "id_token", "code id_token"}
+		}
+		if len(cfg.SubjectTypesSupported) == 0 {
+			cfg.SubjectTypesSupported = []string{"public"}
+		}
+		if len(cfg.IDTokenSigningAlgValuesSupported) == 0 {
+			cfg.IDTokenSigningAlgValuesSupported = []string{"RS256"}
+		}
+		if len(cfg.TokenEndpointAuthMethodsSupported) == 0 {
+			cfg.TokenEndpointAuthMethodsSupported = []string{"client_secret_basic", "client_secret_post"}
+		}
+		if len(cfg.GrantTypesSupported) == 0 {
+			cfg.GrantTypesSupported = []string{"authorization_code", "implicit", "refresh_token"}
+		}
+		if len(cfg.ScopesSupported) == 0 {
+			cfg.ScopesSupported = []string{"openid", "profile", "email", "address", "phone", "offline_access"}
+		}
+		if len(cfg.CodeChallengeMethodsSupported) == 0 {
+			cfg.CodeChallengeMethodsSupported = []string{"S256"}
+		}
+		if len(cfg.ClaimsSupported) == 0 {
+			cfg.ClaimsSupported = []string{"sub", "iss", "aud", "exp", "iat", "name", "email", "email_verified"}
+		}
+
+		cfg.FillDefaultValuesIfEmpty()
+	}
+}
+
+// WithOAuthAuthorizationServerAlias additionally serves the metadata
+// document at WellKnownOAuthAuthorizationServerPath, for RFC 8414 clients
+// that don't know to look under WellKnownOpenIDConfigurationPath.
+func WithOAuthAuthorizationServerAlias() Option {
+	return func(_ *Configuration, o *handlerOptions) {
+		o.serveOAuthAuthorizationServerAlias = true
+	}
+}
+
+// NewConfigurationHandler serves cfg as provider metadata JSON at
+// WellKnownOpenIDConfigurationPath, for use by OPs that want to publish
+// their own discovery document. It panics if, once opts have been applied,
+// cfg doesn't pass Valid.
+func NewConfigurationHandler(cfg Configuration, opts ...Option) http.Handler {
+	o := &handlerOptions{}
+	for _, opt := range opts {
+		opt(&cfg, o)
+	}
+
+	if err := cfg.Valid(); err != nil {
+		panic(errors.Wrap(err, "authproxy/oidc: invalid configuration"))
+	}
+
+	body, err := json.Marshal(cfg)
+	if err != nil {
+		panic(errors.Wrap(err, "authproxy/oidc: couldn't marshal configuration"))
+	}
+
+	serve := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=3600")
+		_, _ = w.Write(body)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(WellKnownOpenIDConfigurationPath, serve)
+	if o.serveOAuthAuthorizationServerAlias {
+		mux.HandleFunc(WellKnownOAuthAuthorizationServerPath, serve)
+	}
+
+	return mux
+}
@@ -0,0 +1,255 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// defaultJWKSCacheTTL is used when the JWKS endpoint doesn't send a
+// Cache-Control or Expires header telling us how long the response may be
+// cached for.
+const defaultJWKSCacheTTL = 5 * time.Minute
+
+// defaultJWKSMinRefreshInterval bounds how often a kid miss against an
+// otherwise still-fresh key set can force a refetch of the JWK Set, so that
+// tokens carrying unknown kids can't be used to repeatedly hammer the
+// provider's JWKS endpoint. It does not delay a refresh once the cached set
+// has actually expired.
+const defaultJWKSMinRefreshInterval = 1 * time.Minute
+
+// JWKSClient fetches and caches the JSON Web Key Set published at a
+// Configuration's JWKSURI, and looks up keys by kid. It's safe for
+// concurrent use.
+type JWKSClient struct {
+	config             Configuration
+	httpClient         *http.Client
+	minRefreshInterval time.Duration
+
+	mu                 sync.Mutex
+	keys               map[string]*jose.JSONWebKey
+	expires            time.Time
+	lastKidMissRefresh time.Time
+	refreshing         bool
+	refreshDone        chan struct{}
+	refreshErr         error
+}
+
+// JWKSClientOption configures a JWKSClient.
+type JWKSClientOption func(*JWKSClient)
+
+// WithJWKSHTTPClient sets the HTTP client used to fetch the JWK Set.
+// Defaults to http.DefaultClient.
+func WithJWKSHTTPClient(client *http.Client) JWKSClientOption {
+	return func(c *JWKSClient) {
+		c.httpClient = client
+	}
+}
+
+// WithJWKSMinRefreshInterval overrides how often GetKey is allowed to refetch
+// a still-fresh JWK Set in response to a kid miss. Defaults to
+// defaultJWKSMinRefreshInterval.
+func WithJWKSMinRefreshInterval(d time.Duration) JWKSClientOption {
+	return func(c *JWKSClient) {
+		c.minRefreshInterval = d
+	}
+}
+
+// NewJWKSClient constructs a JWKSClient for the JWK Set published at
+// config.JWKSURI. Keys aren't fetched until the first call to GetKey.
+func NewJWKSClient(config Configuration, opts ...JWKSClientOption) *JWKSClient {
+	c := &JWKSClient{
+		config:             config,
+		httpClient:         http.DefaultClient,
+		minRefreshInterval: defaultJWKSMinRefreshInterval,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// GetKey returns the JSON Web Key with the given kid. If the cached key set
+// has expired, it's always refreshed before giving up, so that a key
+// rotated in since the last fetch is picked up. If the cached set is still
+// fresh but kid isn't in it, a refresh is only allowed once every
+// minRefreshInterval, so tokens carrying unknown kids can't be used to
+// force unbounded refetches; a miss inside that window is reported as not
+// found without refetching. Callers that arrive while a refresh triggered
+// by another goroutine is already in flight wait for it instead of either
+// refetching again or failing the race.
+func (c *JWKSClient) GetKey(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	key, expired, found := c.lookup(kid)
+	if found {
+		return key, nil
+	}
+
+	if !expired && !c.tryStartKidMissRefresh() {
+		return nil, errors.Errorf("authproxy/oidc: no key with kid %q in JWK Set at %s (refresh rate-limited)", kid, c.config.JWKSURI)
+	}
+
+	if err := c.refreshCoalesced(ctx); err != nil {
+		return nil, err
+	}
+
+	key, _, found = c.lookup(kid)
+	if !found {
+		return nil, errors.Errorf("authproxy/oidc: no key with kid %q in JWK Set at %s", kid, c.config.JWKSURI)
+	}
+
+	return key, nil
+}
+
+// lookup returns the cached key for kid, along with whether the cache has
+// expired and whether the key was found in it (always false if expired).
+func (c *JWKSClient) lookup(kid string) (key *jose.JSONWebKey, expired bool, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Now().After(c.expires) {
+		return nil, true, false
+	}
+
+	key, found = c.keys[kid]
+
+	return key, false, found
+}
+
+// tryStartKidMissRefresh reports whether enough time has passed since the
+// last kid-miss-triggered refresh to allow another one, and records this
+// attempt's time if so. It only governs refreshes of an otherwise still-
+// fresh cache; an expired cache is always refreshed. It also reports true
+// while a refresh is already in flight, so the caller joins that refresh
+// through refreshCoalesced instead of failing.
+func (c *JWKSClient) tryStartKidMissRefresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.refreshing {
+		return true
+	}
+
+	if time.Now().Before(c.lastKidMissRefresh.Add(c.minRefreshInterval)) {
+		return false
+	}
+
+	c.lastKidMissRefresh = time.Now()
+
+	return true
+}
+
+// refreshCoalesced runs refresh, or, if a refresh is already in flight on
+// another goroutine, waits for it to finish and returns its result instead
+// of starting a second concurrent fetch.
+func (c *JWKSClient) refreshCoalesced(ctx context.Context) error {
+	c.mu.Lock()
+	if c.refreshing {
+		done := c.refreshDone
+		c.mu.Unlock()
+
+		select {
+		case <-done:
+			c.mu.Lock()
+			err := c.refreshErr
+			c.mu.Unlock()
+
+			return err
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "authproxy/oidc: context done while waiting for JWKS refresh")
+		}
+	}
+
+	done := make(chan struct{})
+	c.refreshing = true
+	c.refreshDone = done
+	c.mu.Unlock()
+
+	err := c.refresh(ctx)
+
+	c.mu.Lock()
+	c.refreshErr = err
+	c.refreshing = false
+	c.refreshDone = nil
+	c.mu.Unlock()
+	close(done)
+
+	return err
+}
+
+func (c *JWKSClient) refresh(ctx context.Context) (err error) {
+	req, err := http.NewRequest(http.MethodGet, c.config.JWKSURI, nil)
+	if err != nil {
+		return errors.Wrap(err, "authproxy/oidc: couldn't create JWKS request")
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "authproxy/oidc: JWKS request failed")
+	}
+
+	defer func() {
+		errClose := resp.Body.Close()
+		if errClose != nil && err == nil {
+			err = errors.Wrap(errClose, "authproxy/oidc: couldn't close JWKS response body")
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("authproxy/oidc: JWKS endpoint received non-200 status code: %d", resp.StatusCode)
+	}
+
+	var set jose.JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return errors.Wrap(err, "authproxy/oidc: couldn't decode JWKS response body to JSON")
+	}
+
+	keys := make(map[string]*jose.JSONWebKey, len(set.Keys))
+	for i := range set.Keys {
+		key := set.Keys[i]
+		keys[key.KeyID] = &key
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.expires = time.Now().Add(jwksCacheTTL(resp.Header))
+	c.mu.Unlock()
+
+	return nil
+}
+
+// jwksCacheTTL derives how long a JWKS response may be cached for from its
+// Cache-Control max-age directive, falling back to the Expires header and
+// finally to defaultJWKSCacheTTL.
+func jwksCacheTTL(header http.Header) time.Duration {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+
+		seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+		if err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			if ttl := time.Until(t); ttl > 0 {
+				return ttl
+			}
+		}
+	}
+
+	return defaultJWKSCacheTTL
+}
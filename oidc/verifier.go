@@ -0,0 +1,117 @@
+package oidc
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// clockSkew is the leeway allowed when checking the exp, nbf and iat claims.
+const clockSkew = 1 * time.Minute
+
+// Claims are the standard OpenID Connect Core claims carried by an ID Token.
+type Claims struct {
+	Issuer    string           `json:"iss"`
+	Subject   string           `json:"sub"`
+	Audience  jwt.Audience     `json:"aud"`
+	Expiry    *jwt.NumericDate `json:"exp"`
+	IssuedAt  *jwt.NumericDate `json:"iat"`
+	NotBefore *jwt.NumericDate `json:"nbf,omitempty"`
+	Nonce     string           `json:"nonce,omitempty"`
+	AuthTime  *jwt.NumericDate `json:"auth_time,omitempty"`
+}
+
+// Verifier validates ID Tokens issued by an OIDC provider against its
+// Configuration and published JSON Web Key Set.
+type Verifier struct {
+	config Configuration
+	keys   *JWKSClient
+}
+
+// NewVerifier constructs a Verifier for config, fetching signing keys
+// through keys.
+func NewVerifier(config Configuration, keys *JWKSClient) *Verifier {
+	return &Verifier{
+		config: config,
+		keys:   keys,
+	}
+}
+
+// Verify checks rawIDToken's signature, issuer, audience, and timing claims
+// per OpenID Connect Core 1.0 Section 3.1.3.7, and returns its claims.
+func (v *Verifier) Verify(ctx context.Context, rawIDToken string, audience string) (Claims, error) {
+	var claims Claims
+
+	token, err := jwt.ParseSigned(rawIDToken)
+	if err != nil {
+		return claims, errors.Wrap(err, "authproxy/oidc: couldn't parse ID token")
+	}
+
+	if len(token.Headers) != 1 {
+		return claims, errors.New("authproxy/oidc: ID token must have exactly one signature")
+	}
+
+	alg := token.Headers[0].Algorithm
+	if !v.algSupported(alg) {
+		return claims, errors.Errorf("authproxy/oidc: ID token signing algorithm %q isn't supported by this issuer", alg)
+	}
+
+	key, err := v.keys.GetKey(ctx, token.Headers[0].KeyID)
+	if err != nil {
+		return claims, errors.Wrap(err, "authproxy/oidc: couldn't look up ID token signing key")
+	}
+
+	if err := token.Claims(key, &claims); err != nil {
+		return claims, errors.Wrap(err, "authproxy/oidc: ID token signature verification failed")
+	}
+
+	if err := v.validateClaims(claims, audience); err != nil {
+		return claims, err
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) algSupported(alg string) bool {
+	for _, supported := range v.config.IDTokenSigningAlgValuesSupported {
+		if supported == alg {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v *Verifier) validateClaims(claims Claims, audience string) error {
+	if claims.Issuer != v.config.Issuer {
+		return errors.Errorf("authproxy/oidc: ID token has issuer %q, expected %q", claims.Issuer, v.config.Issuer)
+	}
+
+	if !claims.Audience.Contains(audience) {
+		return errors.Errorf("authproxy/oidc: ID token audience %v doesn't contain %q", claims.Audience, audience)
+	}
+
+	now := time.Now()
+
+	if claims.Expiry == nil {
+		return errors.New("authproxy/oidc: ID token is missing the exp claim")
+	}
+	if now.After(claims.Expiry.Time().Add(clockSkew)) {
+		return errors.New("authproxy/oidc: ID token has expired")
+	}
+
+	if claims.IssuedAt == nil {
+		return errors.New("authproxy/oidc: ID token is missing the iat claim")
+	}
+	if now.Before(claims.IssuedAt.Time().Add(-clockSkew)) {
+		return errors.New("authproxy/oidc: ID token was issued in the future")
+	}
+
+	if claims.NotBefore != nil && now.Before(claims.NotBefore.Time().Add(-clockSkew)) {
+		return errors.New("authproxy/oidc: ID token isn't valid yet")
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// oidcIssuerRel is the WebFinger link relation type identifying an OpenID
+// Connect Issuer, as defined by OpenID Connect Discovery 1.0 Section 2.
+const oidcIssuerRel = "http://openid.net/specs/connect/1.0/issuer"
+
+// webfingerDocument is a JSON Resource Descriptor, as returned by a
+// WebFinger endpoint. See RFC 7033 Section 4.4.
+type webfingerDocument struct {
+	Subject string `json:"subject"`
+	Links   []struct {
+		Rel  string `json:"rel"`
+		Href string `json:"href"`
+	} `json:"links"`
+}
+
+// DiscoverIssuer resolves an OIDC Issuer Identifier from a user-supplied
+// identifier using WebFinger, as described by OpenID Connect Discovery 1.0
+// Section 2. resource may be an email address ("user@host"), an acct: URI
+// ("acct:user@host"), or a URL.
+func DiscoverIssuer(ctx context.Context, client *http.Client, resource string) (string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	host, err := webfingerHost(resource)
+	if err != nil {
+		return "", err
+	}
+
+	u := url.URL{
+		Scheme: "https",
+		Host:   host,
+		Path:   "/.well-known/webfinger",
+	}
+	q := u.Query()
+	q.Set("resource", normalizeWebfingerResource(resource))
+	q.Set("rel", oidcIssuerRel)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", errors.Wrap(err, "authproxy/oidc: couldn't create WebFinger request")
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "authproxy/oidc: WebFinger request failed")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("authproxy/oidc: WebFinger endpoint received non-200 status code: %d", resp.StatusCode)
+	}
+
+	var doc webfingerDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return "", errors.Wrap(err, "authproxy/oidc: couldn't decode WebFinger response body to JSON")
+	}
+
+	for _, link := range doc.Links {
+		if link.Rel == oidcIssuerRel && link.Href != "" {
+			return strings.TrimSuffix(link.Href, "/"), nil
+		}
+	}
+
+	return "", errors.Errorf("authproxy/oidc: WebFinger response for %q didn't contain an issuer link", resource)
+}
+
+// normalizeWebfingerResource rewrites a bare email-like identifier
+// ("user@host") to the acct: URI form ("acct:user@host") required by OpenID
+// Connect Discovery 1.0 Section 2 / RFC 7033, leaving URLs and already-
+// prefixed acct: URIs untouched. Some WebFinger servers (e.g. Google) reject
+// the un-prefixed resource value.
+func normalizeWebfingerResource(resource string) string {
+	if strings.HasPrefix(resource, "acct:") || strings.Contains(resource, "://") {
+		return resource
+	}
+	if strings.Contains(resource, "@") {
+		return "acct:" + resource
+	}
+
+	return resource
+}
+
+// webfingerHost derives the host (with port, if any) to query WebFinger on
+// from a user-supplied identifier: an email-like "user@host", an
+// "acct:user@host" URI, or a URL.
+func webfingerHost(resource string) (string, error) {
+	resource = strings.TrimPrefix(resource, "acct:")
+
+	if !strings.Contains(resource, "://") && strings.Contains(resource, "@") {
+		parts := strings.SplitN(resource, "@", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return "", errors.Errorf("authproxy/oidc: couldn't derive a host from identifier %q", resource)
+		}
+
+		return parts[1], nil
+	}
+
+	u, err := url.Parse(resource)
+	if err != nil {
+		return "", errors.Wrapf(err, "authproxy/oidc: couldn't parse identifier %q as a URL", resource)
+	}
+	if u.Host == "" {
+		return "", errors.Errorf("authproxy/oidc: couldn't derive a host from identifier %q", resource)
+	}
+
+	return u.Host, nil
+}
+
+// Discover resolves a Configuration from a user-supplied input, which may be
+// a full issuer URL or an identifier DiscoverIssuer can resolve via
+// WebFinger. It then fetches the provider metadata from
+// {issuer}/.well-known/openid-configuration, applying opts the same way
+// GetOpenIDConnectConfiguration does.
+func Discover(ctx context.Context, client *http.Client, input string, opts ...ConfigurationOption) (Configuration, error) {
+	o := &configurationOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	issuer := strings.TrimSuffix(input, "/")
+
+	if !strings.HasPrefix(issuer, "https://") && !strings.HasPrefix(issuer, "http://") {
+		discovered, err := DiscoverIssuer(ctx, client, input)
+		if err != nil {
+			return Configuration{}, errors.Wrap(err, "authproxy/oidc: couldn't discover issuer via WebFinger")
+		}
+		issuer = discovered
+	}
+
+	if !o.allowInsecureEndpoints && !strings.HasPrefix(issuer, "https://") {
+		return Configuration{}, errors.Wrapf(ErrInsecureEndpoint, "%q", issuer)
+	}
+
+	return GetOpenIDConnectConfiguration(ctx, client, issuer+"/.well-known/openid-configuration", opts...)
+}